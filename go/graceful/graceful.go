@@ -0,0 +1,272 @@
+// Package graceful wraps *http.Server with the signal-handling,
+// Shutdown, and background-job draining dance that every example in
+// this repo otherwise has to reimplement in main.
+package graceful
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const defaultShutdownTimeout = 5 * time.Second
+
+// JobFunc is background work kicked off by a request handler. It
+// receives the server's shared jobs context, which is cancelled once
+// the jobs grace period expires, so long-running work can observe
+// shutdown and abort instead of leaking past process exit.
+type JobFunc func(ctx context.Context) error
+
+// Options configures a Server. The zero value is usable: Signals
+// defaults to SIGINT/SIGTERM, ShutdownTimeout defaults to 5s, and a
+// zero JobsGracePeriod means Serve waits for jobs indefinitely.
+type Options struct {
+	// Signals are the signals that trigger shutdown.
+	Signals []os.Signal
+
+	// ShutdownTimeout bounds how long httpServer.Shutdown is given to
+	// drain in-flight requests.
+	ShutdownTimeout time.Duration
+
+	// JobsGracePeriod bounds how long Serve waits for registered jobs
+	// to finish after the HTTP server has stopped. Zero means wait
+	// forever.
+	JobsGracePeriod time.Duration
+
+	// PreShutdownDelay is how long the readiness probe reports
+	// unready before httpServer.Shutdown is called, giving a load
+	// balancer or kube-proxy time to stop routing new traffic here
+	// first. Zero skips the delay.
+	PreShutdownDelay time.Duration
+
+	// ReadinessPath is where ReadinessHandler should be mounted.
+	// Defaults to "/readyz".
+	ReadinessPath string
+}
+
+const defaultReadinessPath = "/readyz"
+
+func (o Options) withDefaults() Options {
+	if len(o.Signals) == 0 {
+		o.Signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	if o.ShutdownTimeout <= 0 {
+		o.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if o.ReadinessPath == "" {
+		o.ReadinessPath = defaultReadinessPath
+	}
+	return o
+}
+
+// Server ties an *http.Server to pre/post shutdown hooks and a
+// registry of background jobs started by its handlers.
+type Server struct {
+	httpServer *http.Server
+	opts       Options
+
+	preHooks  []func()
+	postHooks []func()
+
+	jobs       sync.WaitGroup
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+
+	ready atomic.Bool
+}
+
+// NewServer wraps httpServer for graceful startup and shutdown. The
+// server reports ready as soon as it's constructed; see
+// ReadinessHandler.
+func NewServer(httpServer *http.Server, opts Options) *Server {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	s := &Server{
+		httpServer: httpServer,
+		opts:       opts.withDefaults(),
+		jobsCtx:    jobsCtx,
+		cancelJobs: cancelJobs,
+	}
+	s.ready.Store(true)
+	return s
+}
+
+// HealthzHandler reports liveness: 200 for as long as the process is
+// up, regardless of readiness.
+func (s *Server) HealthzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ReadinessPath is where ReadinessHandler should be mounted, from
+// Options.ReadinessPath (default "/readyz").
+func (s *Server) ReadinessPath() string {
+	return s.opts.ReadinessPath
+}
+
+// ReadinessHandler reports 200 until shutdown starts draining, then
+// 503 so a load balancer stops routing new traffic here.
+func (s *Server) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if s.ready.Load() {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+// PreHook registers fn to run after a shutdown signal is received but
+// before httpServer.Shutdown is called.
+func (s *Server) PreHook(fn func()) {
+	s.preHooks = append(s.preHooks, fn)
+}
+
+// PostHook registers fn to run after the HTTP server and all
+// registered jobs have finished, right before Serve returns.
+func (s *Server) PostHook(fn func()) {
+	s.postHooks = append(s.postHooks, fn)
+}
+
+// HandleSignals overrides which signals trigger shutdown (the default
+// is SIGINT and SIGTERM).
+func (s *Server) HandleSignals(sig ...os.Signal) {
+	s.opts.Signals = sig
+}
+
+// RegisterJob runs fn in a goroutine tracked by the server, passing
+// the shared jobs context so fn can observe the jobs grace period.
+// The tracking goroutine logs fn's start and completion under name.
+func (s *Server) RegisterJob(name string, fn JobFunc) {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		logf("starting job %q at %s\n", name, time.Now())
+		if err := fn(s.jobsCtx); err != nil {
+			logf("job %q failed: %s\n", name, err)
+			return
+		}
+		logf("finished job %q at %s\n", name, time.Now())
+	}()
+}
+
+// Go is an alias for RegisterJob.
+func (s *Server) Go(name string, fn JobFunc) {
+	s.RegisterJob(name, fn)
+}
+
+// Serve starts the HTTP server, blocks until a shutdown signal
+// arrives, and drains in-flight requests and registered jobs before
+// returning.
+func (s *Server) Serve() error {
+	return s.serve(func() error {
+		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
+}
+
+// ServeTLS is the TLS equivalent of Serve.
+func (s *Server) ServeTLS(certFile, keyFile string) error {
+	return s.serve(func() error {
+		if err := s.httpServer.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *Server) serve(listen func() error) error {
+	errChan := make(chan error, 1)
+	go func() {
+		logf("http server starting\n")
+		errChan <- listen()
+	}()
+
+	termChan := make(chan os.Signal, 1)
+	signal.Notify(termChan, s.opts.Signals...)
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			logf("listen failed %s\n", err)
+		}
+		return err
+	case sig := <-termChan:
+		logf("received signal %q\n", sig)
+	}
+
+	s.ready.Store(false)
+	logf("readiness flipped to unready\n")
+
+	if s.opts.PreShutdownDelay > 0 {
+		logf("waiting %s before shutting down\n", s.opts.PreShutdownDelay)
+		time.Sleep(s.opts.PreShutdownDelay)
+	}
+
+	for _, hook := range s.preHooks {
+		hook()
+	}
+
+	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancelShutdown()
+
+	if err := s.httpServer.Shutdown(ctxShutdown); err != nil {
+		logf("http server shutdown failed, %s\n", err)
+		return err
+	}
+
+	s.waitForJobs(termChan)
+
+	for _, hook := range s.postHooks {
+		hook()
+	}
+
+	logf("http server is exiting\n")
+	return nil
+}
+
+// waitForJobs blocks until every registered job has returned. It is
+// cut short by the jobs grace period expiring or by a second shutdown
+// signal arriving on termChan, either of which cancels the shared jobs
+// context so well-behaved jobs can abort.
+func (s *Server) waitForJobs(termChan <-chan os.Signal) {
+	logf("waiting jobs to finish\n")
+
+	jobsDone := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(jobsDone)
+	}()
+
+	var gracePeriod <-chan time.Time
+	if s.opts.JobsGracePeriod > 0 {
+		gracePeriod = time.After(s.opts.JobsGracePeriod)
+	}
+
+	select {
+	case <-jobsDone:
+		logf("jobs have finished\n")
+		return
+	case <-gracePeriod:
+		logf("wait jobs to finish timeout, cancelling remaining jobs\n")
+		s.cancelJobs()
+	case sig := <-termChan:
+		logf("received second signal %q, forcing job cancellation\n", sig)
+		s.cancelJobs()
+	}
+
+	<-jobsDone
+	logf("jobs have finished\n")
+}
+
+func logf(format string, v ...interface{}) {
+	log.Printf("[graceful] "+format, v...)
+}
@@ -7,34 +7,31 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
-	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
+
+	"github.com/veezhang/graceful-termination-examples/graceful"
 )
 
 type MyHandler struct {
-	wg *sync.WaitGroup
+	srv *graceful.Server
 }
 
-func NewMyHandler(wg *sync.WaitGroup) *MyHandler {
-	return &MyHandler{wg: wg}
+func NewMyHandler(srv *graceful.Server) *MyHandler {
+	return &MyHandler{srv: srv}
 }
 
 func (h *MyHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
 	w.Write([]byte("job started"))
-	h.wg.Add(3)
-	go h.slowJob("job1")
-	go h.slowJob("job2")
-	go h.slowJob("job3")
+	h.srv.Go("job1", h.slowJob)
+	h.srv.Go("job2", h.slowJob)
+	h.srv.Go("job3", h.slowJob)
 }
 
-func (h *MyHandler) slowJob(name string) {
-	defer h.wg.Done()
-	logServer("starting job %q at %s\n", name, time.Now())
+func (h *MyHandler) slowJob(ctx context.Context) error {
 	time.Sleep(time.Duration(1+rand.Intn(4)) * time.Second)
-	logServer("finished job %q at %s\n", name, time.Now())
+	return nil
 }
 
 func mockRequestAndTermination() {
@@ -54,46 +51,21 @@ func mockRequestAndTermination() {
 }
 
 func main() {
-	wg := &sync.WaitGroup{}
 	mux := http.NewServeMux()
-	mux.Handle("/", NewMyHandler(wg))
-	httpServer := http.Server{
+	httpServer := &http.Server{
 		Addr:    "127.0.0.1:8080",
 		Handler: mux,
 	}
 
-	go mockRequestAndTermination()
-	go func() {
-		logServer("[graceful-termination] http server starting\n")
-		if err := httpServer.ListenAndServe(); err != nil {
-			if err != http.ErrServerClosed {
-				logServer("[graceful-termination] listen failed %s\n", err)
-				os.Exit(1)
-			}
-			logServer("[graceful-termination] http server shutdown\n")
-		}
-	}()
-
-	termChan := make(chan os.Signal)
-	signal.Notify(termChan, syscall.SIGTERM, syscall.SIGINT)
-
-	sig := <-termChan
-	logServer("[graceful-termination] received signal %q\n", strings.ToUpper(sig.String()))
-	logServer("[graceful-termination] waiting for shutdown to be initiated")
+	srv := graceful.NewServer(httpServer, graceful.Options{})
+	mux.Handle("/", NewMyHandler(srv))
 
-	ctxShutDown, cancelShutDown := context.WithTimeout(context.Background(), 5*time.Second)
-	defer func() { cancelShutDown() }()
+	go mockRequestAndTermination()
 
-	if err := httpServer.Shutdown(ctxShutDown); err != nil {
-		logServer("[graceful-termination] http server shutdown failed, %s\n", err)
+	if err := srv.Serve(); err != nil {
+		logServer("[graceful-termination] http server exited with error %s\n", err)
 		os.Exit(1)
 	}
-
-	logServer("[graceful-termination] waiting jobs to finish\n")
-	wg.Wait()
-	logServer("[graceful-termination] jobs have finished\n")
-
-	logServer("[graceful-termination] http server is exiting")
 }
 
 func logServer(format string, v ...interface{}){
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+type MyHandler struct {
+	g *errgroup.Group
+}
+
+func NewMyHandler(g *errgroup.Group) *MyHandler {
+	return &MyHandler{g: g}
+}
+
+func (h *MyHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Write([]byte("job started"))
+	h.g.Go(h.slowJob("job1", time.Duration(1+rand.Intn(4))*time.Second))
+	h.g.Go(h.slowJob("job2", time.Duration(1+rand.Intn(4))*time.Second))
+	h.g.Go(h.slowJob("job3", time.Duration(1+rand.Intn(4))*time.Second))
+}
+
+func (h *MyHandler) slowJob(name string, dur time.Duration) func() error {
+	return func() error {
+		logServer("starting job %q at %s\n", name, time.Now())
+		time.Sleep(dur)
+		logServer("finished job %q at %s\n", name, time.Now())
+		return nil
+	}
+}
+
+func mockRequestAndTermination() {
+	time.Sleep(1 * time.Second)
+	req, err := http.Get("http://127.0.0.1:8080")
+	if err != nil {
+		panic(err)
+	}
+	defer func() { req.Body.Close() }()
+	msg , _ := io.ReadAll(req.Body)
+	logClient("received: %s", msg)
+
+	time.Sleep(2 * time.Second)
+
+	logClient("sending signal %q", strings.Title(syscall.SIGINT.String()))
+	syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+}
+
+// main runs the HTTP server, the signal-driven shutdown, and every
+// background job as sibling goroutines under a single errgroup: the
+// first one to fail or the first shutdown signal cancels gctx for all
+// of them, and g.Wait() gives main one clean exit path instead of
+// os.Exit calls scattered across goroutines.
+func main() {
+	sigCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	g, gctx := errgroup.WithContext(sigCtx)
+
+	mux := http.NewServeMux()
+	httpServer := &http.Server{
+		Addr:    "127.0.0.1:8080",
+		Handler: mux,
+	}
+	mux.Handle("/", NewMyHandler(g))
+
+	go mockRequestAndTermination()
+
+	g.Go(func() error {
+		logServer("[graceful-termination] http server starting\n")
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		<-gctx.Done()
+		logServer("[graceful-termination] received shutdown signal\n")
+
+		ctxShutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		return httpServer.Shutdown(ctxShutdown)
+	})
+
+	if err := g.Wait(); err != nil {
+		logServer("[graceful-termination] exiting with error: %s\n", err)
+		os.Exit(1)
+	}
+
+	logServer("[graceful-termination] http server is exiting")
+}
+
+func logServer(format string, v ...interface{}){
+	log.Printf("[S] " + format, v...)
+}
+
+func logClient(format string, v ...interface{}){
+	log.Printf("[C] " + format, v...)
+}
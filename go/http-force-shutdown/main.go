@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/veezhang/graceful-termination-examples/graceful"
+)
+
+type MyHandler struct {
+	srv *graceful.Server
+}
+
+func NewMyHandler(srv *graceful.Server) *MyHandler {
+	return &MyHandler{srv: srv}
+}
+
+func (h *MyHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Write([]byte("job started"))
+	h.srv.Go("job1", h.slowJobFor(time.Duration(1+rand.Intn(4))*time.Second))
+	h.srv.Go("job2", h.slowJobFor(time.Duration(1+rand.Intn(4))*time.Second))
+	h.srv.Go("job3", h.slowJobFor(time.Duration(1+rand.Intn(4))*time.Second))
+	h.srv.Go("job4 very slow", h.slowJobFor(time.Hour))
+}
+
+func (h *MyHandler) slowJobFor(dur time.Duration) graceful.JobFunc {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(dur):
+			return nil
+		case <-ctx.Done():
+			logServer("job cancelled before finishing\n")
+			return ctx.Err()
+		}
+	}
+}
+
+// mockRequestAndTermination sends a first SIGINT to start the normal
+// 30s grace period, then a second SIGINT shortly after to demonstrate
+// that it forces immediate cancellation of the shared jobs context
+// instead of waiting out the rest of the grace period.
+func mockRequestAndTermination() {
+	time.Sleep(1 * time.Second)
+	req, err := http.Get("http://127.0.0.1:8080")
+	if err != nil {
+		panic(err)
+	}
+	defer func() { req.Body.Close() }()
+	msg , _ := io.ReadAll(req.Body)
+	logClient("received: %s", msg)
+
+	time.Sleep(2 * time.Second)
+
+	logClient("sending signal %q", strings.Title(syscall.SIGINT.String()))
+	syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+
+	time.Sleep(2 * time.Second)
+
+	logClient("sending second signal %q to force shutdown", strings.Title(syscall.SIGINT.String()))
+	syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+}
+
+func main() {
+	mux := http.NewServeMux()
+	httpServer := &http.Server{
+		Addr:    "127.0.0.1:8080",
+		Handler: mux,
+	}
+
+	srv := graceful.NewServer(httpServer, graceful.Options{
+		JobsGracePeriod: 30 * time.Second,
+	})
+	mux.Handle("/", NewMyHandler(srv))
+
+	go mockRequestAndTermination()
+
+	if err := srv.Serve(); err != nil {
+		logServer("[graceful-termination] http server exited with error %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func logServer(format string, v ...interface{}){
+	log.Printf("[S] " + format, v...)
+}
+
+func logClient(format string, v ...interface{}){
+	log.Printf("[C] " + format, v...)
+}
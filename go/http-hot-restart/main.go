@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// listenerFDEnv carries the dup'd listener fd from parent to child
+// across a hot restart, the same mechanism systemd socket activation
+// and tools like gitaly's bootstrap package use.
+const listenerFDEnv = "GRACEFUL_LISTENER_FD"
+
+// listen reconstructs the listener from listenerFDEnv when present
+// (we are the child of a hot restart), or opens a fresh one otherwise.
+func listen(addr string) (net.Listener, error) {
+	if fdStr := os.Getenv(listenerFDEnv); fdStr != "" {
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", listenerFDEnv, err)
+		}
+		return net.FileListener(os.NewFile(uintptr(fd), "listener"))
+	}
+	return net.Listen("tcp", addr)
+}
+
+// reexecListener dups ln's file descriptor, re-execs the current
+// binary with it passed through as ExtraFiles, and points the child at
+// it via listenerFDEnv so it can start accepting immediately while
+// this process drains its in-flight requests.
+func reexecListener(ln net.Listener) error {
+	tcpLn, ok := ln.(*net.TCPListener)
+	if !ok {
+		return fmt.Errorf("listener %T does not support fd handoff", ln)
+	}
+
+	file, err := tcpLn.File()
+	if err != nil {
+		return fmt.Errorf("dup listener fd: %w", err)
+	}
+	defer file.Close()
+
+	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd.Env = append(os.Environ(), listenerFDEnv+"=3")
+	cmd.ExtraFiles = []*os.File{file}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Start()
+}
+
+func main() {
+	ln, err := listen("127.0.0.1:8080")
+	if err != nil {
+		logServer("listen failed: %s\n", err)
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(2 * time.Second)
+		fmt.Fprintf(w, "served by pid %d", os.Getpid())
+	})
+	httpServer := &http.Server{Handler: mux}
+
+	errChan := make(chan error, 1)
+	go func() {
+		logServer("http server starting\n")
+		errChan <- httpServer.Serve(ln)
+	}()
+
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	termChan := make(chan os.Signal, 1)
+	signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-errChan:
+		if err != nil && err != http.ErrServerClosed {
+			logServer("listen failed: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	case <-hupChan:
+		logServer("received SIGHUP, handing listener off to a new process\n")
+		if err := reexecListener(ln); err != nil {
+			logServer("re-exec failed, staying up: %s\n", err)
+			sig := <-termChan
+			logServer("received signal %q\n", sig)
+		}
+	case sig := <-termChan:
+		logServer("received signal %q\n", sig)
+	}
+
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	logServer("draining in-flight requests\n")
+	if err := httpServer.Shutdown(ctxShutdown); err != nil {
+		logServer("shutdown failed: %s\n", err)
+		os.Exit(1)
+	}
+
+	logServer("http server is exiting\n")
+}
+
+func logServer(format string, v ...interface{}) {
+	log.Printf("[S pid=%d] "+format, append([]interface{}{os.Getpid()}, v...)...)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const testAddr = "127.0.0.1:8080"
+
+var pidLineRE = regexp.MustCompile(`\[S pid=(\d+)\]`)
+
+// TestHotRestartZeroRefusals builds the example binary, starts it,
+// dials its listener in a tight loop, and sends SIGHUP partway through
+// to trigger a hot restart. It asserts none of the dials are refused,
+// proving the fd handoff keeps the socket accepting connections the
+// whole time.
+func TestHotRestartZeroRefusals(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs a real subprocess; skipped with -short")
+	}
+
+	bin := buildBinary(t)
+
+	cmd := exec.Command(bin)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("stdout pipe: %v", err)
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start binary: %v", err)
+	}
+
+	pids := trackPids(stdout, cmd.Process.Pid)
+	defer killAll(pids)
+
+	waitForListener(t, testAddr)
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_ = cmd.Process.Signal(syscall.SIGHUP)
+	}()
+
+	var total, refused int
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		total++
+		conn, err := net.DialTimeout("tcp", testAddr, 200*time.Millisecond)
+		if err != nil {
+			refused++
+			continue
+		}
+		conn.Close()
+	}
+
+	if refused != 0 {
+		t.Fatalf("%d/%d dials were refused across the hot restart", refused, total)
+	}
+}
+
+func buildBinary(t *testing.T) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "http-hot-restart")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Stdout = os.Stderr
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		t.Fatalf("go build: %v", err)
+	}
+	return bin
+}
+
+func waitForListener(t *testing.T, addr string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("server never started listening on %s", addr)
+}
+
+// trackPids tails r for "[S pid=N]" log lines, recording every pid the
+// binary logs under. A hot restart re-execs into a second process the
+// test never started directly, so this is how the test finds it to
+// clean it up afterwards.
+func trackPids(r io.Reader, initialPid int) *sync.Map {
+	pids := &sync.Map{}
+	pids.Store(initialPid, struct{}{})
+
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if m := pidLineRE.FindStringSubmatch(scanner.Text()); m != nil {
+				if pid, err := strconv.Atoi(m[1]); err == nil {
+					pids.Store(pid, struct{}{})
+				}
+			}
+		}
+	}()
+
+	return pids
+}
+
+func killAll(pids *sync.Map) {
+	pids.Range(func(key, _ interface{}) bool {
+		_ = syscall.Kill(key.(int), syscall.SIGKILL)
+		return true
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/veezhang/graceful-termination-examples/graceful"
+)
+
+// selfSignedCert generates an in-memory self-signed certificate for
+// 127.0.0.1 so `go run ./http-tls` works without any external cert or
+// key files.
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+type MyHandler struct{}
+
+func NewMyHandler() *MyHandler {
+	return &MyHandler{}
+}
+
+// ServeHTTP streams a few chunks a second apart so the demo can show
+// httpServer.Shutdown waiting for an in-flight HTTP/2 response to
+// finish instead of cutting it off.
+func (h *MyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, _ := w.(http.Flusher)
+	logServer("serving %s request from %s\n", r.Proto, r.RemoteAddr)
+
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(w, "chunk %d at %s\n", i, time.Now())
+		if flusher != nil {
+			flusher.Flush()
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
+
+func mockRequestAndTermination() {
+	time.Sleep(1 * time.Second)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		},
+	}
+	resp, err := client.Get("https://127.0.0.1:8443")
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	go func() {
+		time.Sleep(2 * time.Second)
+		logClient("sending signal %q while the response is still streaming", strings.Title(syscall.SIGINT.String()))
+		syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+	}()
+
+	body, _ := io.ReadAll(resp.Body)
+	logClient("received %d bytes once the stream finished: %q", len(body), body)
+}
+
+func main() {
+	cert, err := selfSignedCert()
+	if err != nil {
+		logServer("failed to generate self-signed cert: %s\n", err)
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", NewMyHandler())
+	httpServer := &http.Server{
+		Addr:    "127.0.0.1:8443",
+		Handler: mux,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h2", "http/1.1"},
+		},
+	}
+
+	srv := graceful.NewServer(httpServer, graceful.Options{})
+
+	go mockRequestAndTermination()
+
+	// Certificates are already set on httpServer.TLSConfig, so no
+	// cert/key files are needed here.
+	if err := srv.ServeTLS("", ""); err != nil {
+		logServer("[graceful-termination] http server exited with error %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func logServer(format string, v ...interface{}){
+	log.Printf("[S] " + format, v...)
+}
+
+func logClient(format string, v ...interface{}){
+	log.Printf("[C] " + format, v...)
+}
@@ -7,35 +7,39 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
-	"os/signal"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
+
+	"github.com/veezhang/graceful-termination-examples/graceful"
 )
 
 type MyHandler struct {
-	wg *sync.WaitGroup
+	srv *graceful.Server
 }
 
-func NewMyHandler(wg *sync.WaitGroup) *MyHandler {
-	return &MyHandler{wg: wg}
+func NewMyHandler(srv *graceful.Server) *MyHandler {
+	return &MyHandler{srv: srv}
 }
 
 func (h *MyHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
 	w.Write([]byte("job started"))
-	h.wg.Add(4)
-	go h.slowJob("job1", time.Duration(1+rand.Intn(4)) * time.Second)
-	go h.slowJob("job2", time.Duration(1+rand.Intn(4)) * time.Second)
-	go h.slowJob("job3", time.Duration(1+rand.Intn(4)) * time.Second)
-	go h.slowJob("job4 very slow", time.Hour)
+	h.srv.Go("job1", h.slowJobFor(time.Duration(1+rand.Intn(4))*time.Second))
+	h.srv.Go("job2", h.slowJobFor(time.Duration(1+rand.Intn(4))*time.Second))
+	h.srv.Go("job3", h.slowJobFor(time.Duration(1+rand.Intn(4))*time.Second))
+	h.srv.Go("job4 very slow", h.slowJobFor(time.Hour))
 }
 
-func (h *MyHandler) slowJob(name string, dur time.Duration) {
-	defer h.wg.Done()
-	logServer("starting job %q at %s\n", name, time.Now())
-	time.Sleep(dur)
-	logServer("finished job %q at %s\n", name, time.Now())
+func (h *MyHandler) slowJobFor(dur time.Duration) graceful.JobFunc {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(dur):
+			return nil
+		case <-ctx.Done():
+			logServer("job cancelled before finishing\n")
+			return ctx.Err()
+		}
+	}
 }
 
 func mockRequestAndTermination() {
@@ -52,60 +56,35 @@ func mockRequestAndTermination() {
 
 	logClient("sending signal %q", strings.Title(syscall.SIGINT.String()))
 	syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+
+	time.Sleep(1 * time.Second)
+	if resp, err := http.Get("http://127.0.0.1:8080/readyz"); err == nil {
+		logClient("/readyz now reports %s during the pre-shutdown delay", resp.Status)
+		resp.Body.Close()
+	}
 }
 
 func main() {
-	wg := &sync.WaitGroup{}
 	mux := http.NewServeMux()
-	mux.Handle("/", NewMyHandler(wg))
-	httpServer := http.Server{
+	httpServer := &http.Server{
 		Addr:    "127.0.0.1:8080",
 		Handler: mux,
 	}
 
-	go mockRequestAndTermination()
-	go func() {
-		logServer("[graceful-termination] http server starting\n")
-		if err := httpServer.ListenAndServe(); err != nil {
-			if err != http.ErrServerClosed {
-				logServer("[graceful-termination] listen failed %s\n", err)
-				os.Exit(1)
-			}
-			logServer("[graceful-termination] http server shutdown\n")
-		}
-	}()
-
-	termChan := make(chan os.Signal)
-	signal.Notify(termChan, syscall.SIGTERM, syscall.SIGINT)
+	srv := graceful.NewServer(httpServer, graceful.Options{
+		JobsGracePeriod:  30 * time.Second,
+		PreShutdownDelay: 10 * time.Second,
+	})
+	mux.Handle("/", NewMyHandler(srv))
+	mux.Handle("/healthz", srv.HealthzHandler())
+	mux.Handle(srv.ReadinessPath(), srv.ReadinessHandler())
 
-	sig := <-termChan
-	logServer("[graceful-termination] received signal %q\n", strings.ToUpper(sig.String()))
-	logServer("[graceful-termination] waiting for shutdown to be initiated")
-
-	ctxShutDown, cancelShutDown := context.WithTimeout(context.Background(), 5*time.Second)
-	defer func() { cancelShutDown() }()
+	go mockRequestAndTermination()
 
-	if err := httpServer.Shutdown(ctxShutDown); err != nil {
-		logServer("[graceful-termination] http server shutdown failed, %s\n", err)
+	if err := srv.Serve(); err != nil {
+		logServer("[graceful-termination] http server exited with error %s\n", err)
 		os.Exit(1)
 	}
-
-	gracePeriod := 30 * time.Second
-	ctxJobs, cancelJobs := context.WithTimeout(context.Background(), gracePeriod)
-	go func() {
-		wg.Wait()
-		cancelJobs()
-	}()
-
-	logServer("[graceful-termination] waiting jobs to finish\n")
-	select {
-	case <-ctxJobs.Done():
-		logServer("[graceful-termination] jobs have finished\n")
-	case <-time.After(gracePeriod):
-		logServer("[graceful-termination] wait jobs to finish timeout\n")
-	}
-
-	logServer("[graceful-termination] http server is exiting")
 }
 
 func logServer(format string, v ...interface{}){